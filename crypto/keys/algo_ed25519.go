@@ -0,0 +1,23 @@
+package keys
+
+import (
+	"crypto/ed25519"
+
+	tcrypto "github.com/tendermint/tendermint/crypto"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/hd"
+)
+
+func ed25519Derive(seed []byte, hdPath string) (tcrypto.PrivKey, error) {
+	masterPriv, ch := hd.ComputeMastersFromSeed(seed)
+	derivedPriv, err := hd.DerivePrivateKeyForPath(masterPriv, ch, hdPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// derivedPriv is the 32 byte secp256k1-style scalar hd.DerivePrivateKeyForPath
+	// produces; treat it as an ed25519 seed and expand it into a full keypair.
+	var priv tcrypto.PrivKeyEd25519
+	copy(priv[:], ed25519.NewKeyFromSeed(derivedPriv[:]))
+	return priv, nil
+}
@@ -0,0 +1,21 @@
+package keys
+
+import (
+	tcrypto "github.com/tendermint/tendermint/crypto"
+
+	"github.com/cosmos/cosmos-sdk/crypto"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/hd"
+)
+
+func secp256k1Derive(seed []byte, hdPath string) (tcrypto.PrivKey, error) {
+	masterPriv, ch := hd.ComputeMastersFromSeed(seed)
+	derivedPriv, err := hd.DerivePrivateKeyForPath(masterPriv, ch, hdPath)
+	if err != nil {
+		return nil, err
+	}
+	return tcrypto.PrivKeySecp256k1(derivedPriv), nil
+}
+
+func secp256k1Ledger(path crypto.DerivationPath) (tcrypto.PrivKey, error) {
+	return crypto.NewPrivKeyLedgerSecp256k1(path)
+}
@@ -0,0 +1,28 @@
+package keys
+
+import (
+	tcrypto "github.com/tendermint/tendermint/crypto"
+
+	"github.com/cosmos/cosmos-sdk/crypto"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/hd"
+)
+
+func secp256k1EthDerive(seed []byte, hdPath string) (tcrypto.PrivKey, error) {
+	masterPriv, ch := hd.ComputeMastersFromSeed(seed)
+	derivedPriv, err := hd.DerivePrivateKeyForPath(masterPriv, ch, hdPath)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.PrivKeySecp256k1Eth(derivedPriv), nil
+}
+
+// registerSecp256k1EthAmino is Secp256k1Eth's registerFn, passed to
+// RegisterSigningAlgo below. A Secp256k1Eth key stored with a passphrase
+// goes through encryptArmorPrivKey, and one stored without goes through
+// writeInfo - both marshal the tcrypto.PrivKey/PubKey interface values with
+// cdc, so the concrete types need to be registered here too, not just on
+// crypto's own internal codec that backs their Bytes() methods.
+func registerSecp256k1EthAmino() {
+	cdc.RegisterConcrete(crypto.PrivKeySecp256k1Eth{}, "cosmos-sdk/PrivKeySecp256k1Eth", nil)
+	cdc.RegisterConcrete(crypto.PubKeySecp256k1Eth{}, "cosmos-sdk/PubKeySecp256k1Eth", nil)
+}
@@ -0,0 +1,145 @@
+// Package bip39 wraps github.com/tyler-smith/go-bip39 with the
+// multi-language support the Keybase needs: callers pick a Language when
+// generating a mnemonic, and that choice is auto-detected again on
+// recovery so it never has to be remembered or passed back in.
+package bip39
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	go_bip39 "github.com/tyler-smith/go-bip39"
+	"github.com/tyler-smith/go-bip39/wordlists"
+)
+
+// Language identifies which official BIP-39 wordlist a mnemonic is
+// generated from, or is checked against during recovery.
+type Language int
+
+const (
+	English Language = iota
+	Japanese
+	Korean
+	Spanish
+	ChineseSimplified
+	ChineseTraditional
+	French
+	Italian
+)
+
+var byLanguage = map[Language][]string{
+	English:            wordlists.English,
+	Japanese:           wordlists.Japanese,
+	Korean:             wordlists.Korean,
+	Spanish:            wordlists.Spanish,
+	ChineseSimplified:  wordlists.ChineseSimplified,
+	ChineseTraditional: wordlists.ChineseTraditional,
+	French:             wordlists.French,
+	Italian:            wordlists.Italian,
+}
+
+// detectionOrder is the order DetectLanguage tries wordlists in. It must be
+// a fixed order - not a range over byLanguage, whose iteration order is
+// randomized - since several of these wordlists share enough words that a
+// given mnemonic can validate against more than one. English goes first as
+// the overwhelmingly common case.
+var detectionOrder = []Language{
+	English,
+	Japanese,
+	Korean,
+	Spanish,
+	ChineseSimplified,
+	ChineseTraditional,
+	French,
+	Italian,
+}
+
+// ErrUnsupportedLanguage is returned for a Language with no loaded wordlist.
+var ErrUnsupportedLanguage = errors.New("bip39: unsupported mnemonic language")
+
+// go-bip39 keeps the active wordlist as package-level state, so every call
+// into it below must hold this lock for as long as that state matters.
+var mu sync.Mutex
+
+// KeyType selects how NewMnemonic sources its entropy.
+type KeyType int
+
+const (
+	// FreshKey draws new, cryptographically secure entropy for a 24 word
+	// (256 bit) mnemonic.
+	FreshKey KeyType = iota
+)
+
+// NewMnemonic generates a new mnemonic sentence from the wordlist for
+// language.
+func NewMnemonic(kt KeyType, language Language) ([]string, error) {
+	words, ok := byLanguage[language]
+	if !ok {
+		return nil, ErrUnsupportedLanguage
+	}
+
+	var entropy []byte
+	var err error
+	switch kt {
+	case FreshKey:
+		entropy, err = go_bip39.NewEntropy(256)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	go_bip39.SetWordList(words)
+	mnemonic, err := go_bip39.NewMnemonic(entropy)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(mnemonic, " "), nil
+}
+
+// MnemonicToSeed converts mnemonic straight to a seed with no BIP-39
+// passphrase and no checksum validation. Kept for callers that already
+// know the mnemonic is well-formed.
+func MnemonicToSeed(mnemonic string) []byte {
+	return go_bip39.NewSeed(mnemonic, "")
+}
+
+// MnemonicToSeedWithErrChecking validates mnemonic's checksum - detecting
+// which of the loaded wordlists it belongs to in the process - before
+// deriving the seed.
+func MnemonicToSeedWithErrChecking(mnemonic string) ([]byte, error) {
+	return MnemonicToSeedWithErrCheckingAndPassphrase(mnemonic, "")
+}
+
+// MnemonicToSeedWithErrCheckingAndPassphrase is MnemonicToSeedWithErrChecking
+// plus the optional BIP-39 passphrase (the "25th word"): a different
+// passphrase derives an entirely different, hidden seed from the same
+// mnemonic, per PBKDF2(mnemonic, "mnemonic"+passphrase, 2048, 64, SHA512).
+func MnemonicToSeedWithErrCheckingAndPassphrase(mnemonic, passphrase string) ([]byte, error) {
+	if _, err := DetectLanguage(mnemonic); err != nil {
+		return nil, err
+	}
+	return go_bip39.NewSeed(mnemonic, passphrase), nil
+}
+
+// DetectLanguage returns the Language whose wordlist validates mnemonic's
+// checksum, so callers recovering a key don't need to remember which
+// language they generated it in.
+func DetectLanguage(mnemonic string) (Language, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, lang := range detectionOrder {
+		words, ok := byLanguage[lang]
+		if !ok {
+			continue
+		}
+		go_bip39.SetWordList(words)
+		if go_bip39.IsMnemonicValid(mnemonic) {
+			return lang, nil
+		}
+	}
+	return 0, errors.New("bip39: mnemonic does not match any supported wordlist")
+}
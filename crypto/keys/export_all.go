@@ -0,0 +1,293 @@
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	bcrypt "github.com/tendermint/crypto/bcrypt"
+)
+
+// bundleBcryptCost is the bcrypt work factor used to stretch the passphrase
+// before it keys the bundle's AES-GCM encryption, matching the cost this
+// package already uses to encrypt individual private keys.
+const bundleBcryptCost = 12
+
+const bundleBlockType = "TENDERMINT KEY BUNDLE"
+
+// keyBundleEntry is one named key as it already sits in the db: the raw,
+// Amino-encoded Info bytes, plus the raw armored seed envelope if name was
+// created with CreateAccount. Re-using the on-disk encoding for both means
+// ExportAll/ImportAll don't need to know how to (de)serialize Info variants
+// or seed records themselves.
+type keyBundleEntry struct {
+	Name string
+	Info []byte
+	// Seed is subaccounts.go's persisted seed for name, or nil if name has
+	// none. It travels in the bundle so DeriveNextAccount still works on
+	// the destination Keybase after a migration - without it, moving a
+	// CreateAccount key would silently strand its subaccounts.
+	Seed []byte
+}
+
+// ExportAll serializes every key in the Keybase - local, ledger and
+// offline, along with any CreateAccount seed it owns - into a single
+// Amino-encoded bundle, encrypts it with a key derived from passphrase
+// (bcrypt salt + AES-GCM, the scheme this package already uses for
+// individual keys), and armors the result so it can be copied to another
+// node in one piece.
+func (kb dbKeybase) ExportAll(passphrase string) ([]byte, error) {
+	byName := make(map[string]*keyBundleEntry)
+	var order []string
+	iter := kb.db.Iterator(nil, nil)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		key := string(iter.Key())
+		var name string
+		var isSeed bool
+		switch {
+		case strings.HasSuffix(key, infoKeySuffix):
+			name = strings.TrimSuffix(key, infoKeySuffix)
+		case strings.HasSuffix(key, seedInfoSuffix):
+			name = strings.TrimSuffix(key, seedInfoSuffix)
+			isSeed = true
+		default:
+			// Not a keyspace ExportAll understands - skip it rather than
+			// bundling arbitrary db state.
+			continue
+		}
+
+		e, ok := byName[name]
+		if !ok {
+			e = &keyBundleEntry{Name: name}
+			byName[name] = e
+			order = append(order, name)
+		}
+		if isSeed {
+			e.Seed = iter.Value()
+		} else {
+			e.Info = iter.Value()
+		}
+	}
+	if len(order) == 0 {
+		return nil, errors.New("keybase has no keys to export")
+	}
+	entries := make([]keyBundleEntry, len(order))
+	for i, name := range order {
+		entries[i] = *byName[name]
+	}
+
+	plaintext := cdc.MustMarshalBinaryBare(entries)
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := bundleKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce, ciphertext, err := bundleSeal(key, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return armorAEADEnvelope(bundleBlockType, salt, nonce, ciphertext), nil
+}
+
+// ImportAll decrypts a bundle produced by ExportAll with passphrase and
+// restores every key it contains. It refuses to clobber a name already
+// present in the Keybase - use ImportAllMergeRename to bring in a bundle
+// that collides with existing names instead.
+func (kb dbKeybase) ImportAll(bz []byte, passphrase string) error {
+	entries, err := kb.decryptBundle(bz, passphrase)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if len(kb.db.Get(infoKey(e.Name))) > 0 {
+			return fmt.Errorf("cannot overwrite data for name %s", e.Name)
+		}
+	}
+	for _, e := range entries {
+		kb.storeBundleEntry(e.Name, e)
+	}
+	return nil
+}
+
+// ImportAllMergeRename behaves like ImportAll, except a name already
+// present in the Keybase is imported under "<name>-2", "<name>-3" and so on
+// instead of erroring out.
+func (kb dbKeybase) ImportAllMergeRename(bz []byte, passphrase string) error {
+	entries, err := kb.decryptBundle(bz, passphrase)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name
+		for i := 2; len(kb.db.Get(infoKey(name))) > 0; i++ {
+			name = fmt.Sprintf("%s-%d", e.Name, i)
+		}
+
+		infoBz := e.Info
+		if name != e.Name {
+			// The Info we decoded still self-reports its original name;
+			// re-encode it under the deduped one so GetName() and the
+			// storage key agree.
+			info, err := readInfo(e.Info)
+			if err != nil {
+				return err
+			}
+			infoBz = writeInfo(renameInfo(info, name))
+		}
+		// The seed record, if any, carries no embedded name of its own -
+		// it only needs to move to the deduped name's seedInfoKey so
+		// DeriveNextAccount keeps working under that name.
+		kb.storeBundleEntry(name, keyBundleEntry{Info: infoBz, Seed: e.Seed})
+	}
+	return nil
+}
+
+// storeBundleEntry writes a decoded bundle entry's Info and, if present,
+// Seed under name - not necessarily entry.Name, since the merge-rename path
+// may have deduped it to something else first.
+func (kb dbKeybase) storeBundleEntry(name string, entry keyBundleEntry) {
+	if entry.Info != nil {
+		kb.db.SetSync(infoKey(name), entry.Info)
+	}
+	if entry.Seed != nil {
+		kb.db.SetSync(seedInfoKey(name), entry.Seed)
+	}
+}
+
+// renameInfo returns a copy of info with its embedded name changed to
+// newName, preserving everything else about it.
+func renameInfo(info Info, newName string) Info {
+	switch i := info.(type) {
+	case localInfo:
+		return newLocalInfo(newName, i.GetPubKey(), i.PrivKeyArmor)
+	case ledgerInfo:
+		return newLedgerInfo(newName, i.GetPubKey(), i.Path)
+	case offlineInfo:
+		return newOfflineInfo(newName, i.GetPubKey())
+	default:
+		return info
+	}
+}
+
+func (kb dbKeybase) decryptBundle(bz []byte, passphrase string) ([]keyBundleEntry, error) {
+	salt, nonce, ciphertext, err := unarmorAEADEnvelope(bundleBlockType, bz)
+	if err != nil {
+		return nil, err
+	}
+	key, err := bundleKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := bundleOpen(key, nonce, ciphertext)
+	if err != nil {
+		return nil, errors.Wrap(err, "wrong passphrase or corrupted bundle")
+	}
+	var entries []keyBundleEntry
+	if err := cdc.UnmarshalBinaryBare(plaintext, &entries); err != nil {
+		return nil, errors.Wrap(err, "corrupted bundle")
+	}
+	return entries, nil
+}
+
+// bundleKey stretches passphrase with bcrypt, salted by salt, into a 32
+// byte AES-256 key.
+func bundleKey(passphrase string, salt []byte) ([]byte, error) {
+	stretched, err := bcrypt.GenerateFromPassword(salt, []byte(passphrase), bundleBcryptCost)
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating bcrypt key from passphrase")
+	}
+	key := sha256.Sum256(stretched)
+	return key[:], nil
+}
+
+func bundleSeal(key, plaintext []byte) (nonce, ciphertext []byte, err error) {
+	gcm, err := newBundleGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, nil), nil
+}
+
+func bundleOpen(key, nonce, ciphertext []byte) ([]byte, error) {
+	gcm, err := newBundleGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newBundleGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// armorAEADEnvelope writes an ASCII envelope of blockType around an
+// AES-GCM encrypted payload, in the same spirit as the single-key armor
+// format this package already uses. It backs both ExportAll's key bundles
+// and the per-account encrypted seeds in subaccounts.go.
+func armorAEADEnvelope(blockType string, salt, nonce, ciphertext []byte) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "-----BEGIN %s-----\n", blockType)
+	fmt.Fprintf(&sb, "kdf: bcrypt\n")
+	fmt.Fprintf(&sb, "salt: %s\n", hex.EncodeToString(salt))
+	fmt.Fprintf(&sb, "nonce: %s\n\n", hex.EncodeToString(nonce))
+	sb.WriteString(base64.StdEncoding.EncodeToString(ciphertext))
+	fmt.Fprintf(&sb, "\n-----END %s-----\n", blockType)
+	return []byte(sb.String())
+}
+
+func unarmorAEADEnvelope(blockType string, bz []byte) (salt, nonce, ciphertext []byte, err error) {
+	lines := strings.Split(strings.TrimSpace(string(bz)), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "-----BEGIN "+blockType) {
+		return nil, nil, nil, errors.Errorf("not a valid %s", strings.ToLower(blockType))
+	}
+
+	var body strings.Builder
+	inBody := false
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "-----END "+blockType) {
+			break
+		}
+		switch {
+		case strings.HasPrefix(line, "salt: "):
+			if salt, err = hex.DecodeString(strings.TrimPrefix(line, "salt: ")); err != nil {
+				return nil, nil, nil, errors.Wrap(err, "invalid salt header")
+			}
+		case strings.HasPrefix(line, "nonce: "):
+			if nonce, err = hex.DecodeString(strings.TrimPrefix(line, "nonce: ")); err != nil {
+				return nil, nil, nil, errors.Wrap(err, "invalid nonce header")
+			}
+		case line == "":
+			inBody = true
+		case inBody:
+			body.WriteString(line)
+		}
+	}
+	if salt == nil || nonce == nil {
+		return nil, nil, nil, errors.Errorf("%s is missing its salt or nonce header", strings.ToLower(blockType))
+	}
+	ciphertext, err = base64.StdEncoding.DecodeString(body.String())
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "invalid envelope body")
+	}
+	return salt, nonce, ciphertext, nil
+}
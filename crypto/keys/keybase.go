@@ -1,9 +1,7 @@
 package keys
 
 import (
-	"bufio"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -17,50 +15,56 @@ import (
 
 var _ Keybase = dbKeybase{}
 
-// Language is a language to create the BIP 39 mnemonic in.
-// Currently, only english is supported though.
-// Find a list of all supported languages in the BIP 39 spec (word lists).
-type Language int
+// Language is the BIP 39 wordlist to create or recover a mnemonic with.
+// See the BIP 39 spec for the full list of official word lists.
+type Language = bip39.Language
 
 const (
-	// English is the default language to create a mnemonic.
-	// It is the only supported language by this package.
-	English Language = iota + 1
-	// Japanese is currently not supported.
-	Japanese
-	// Korean is currently not supported.
-	Korean
-	// Spanish is currently not supported.
-	Spanish
-	// ChineseSimplified is currently not supported.
-	ChineseSimplified
-	// ChineseTraditional is currently not supported.
-	ChineseTraditional
-	// French is currently not supported.
-	French
-	// Italian is currently not supported.
-	Italian
+	English            = bip39.English
+	Japanese           = bip39.Japanese
+	Korean             = bip39.Korean
+	Spanish            = bip39.Spanish
+	ChineseSimplified  = bip39.ChineseSimplified
+	ChineseTraditional = bip39.ChineseTraditional
+	French             = bip39.French
+	Italian            = bip39.Italian
 )
 
 var (
 	// ErrUnsupportedSigningAlgo is raised when the caller tries to use a different signing scheme than secp256k1.
 	ErrUnsupportedSigningAlgo = errors.New("unsupported signing algo: only secp256k1 is supported")
-	// ErrUnsupportedLanguage is raised when the caller tries to use a different language than english for creating
-	// a mnemonic sentence.
-	ErrUnsupportedLanguage = errors.New("unsupported language: only english is supported")
+	// ErrUnsupportedLanguage is raised when the caller tries to use a mnemonic language with no loaded wordlist.
+	ErrUnsupportedLanguage = bip39.ErrUnsupportedLanguage
 )
 
 // dbKeybase combines encryption and storage implementation to provide
 // a full-featured key manager
 type dbKeybase struct {
-	db dbm.DB
+	db            dbm.DB
+	offlineSigner OfflineSigner
+}
+
+// KeybaseOption configures a Keybase at construction time, via New.
+type KeybaseOption func(*dbKeybase)
+
+// WithOfflineSigner routes offlineInfo signing requests to signer instead
+// of the default interactive terminal prompt.
+func WithOfflineSigner(signer OfflineSigner) KeybaseOption {
+	return func(kb *dbKeybase) {
+		kb.offlineSigner = signer
+	}
 }
 
 // New creates a new keybase instance using the passed DB for reading and writing keys.
-func New(db dbm.DB) Keybase {
-	return dbKeybase{
-		db: db,
+func New(db dbm.DB, opts ...KeybaseOption) Keybase {
+	kb := dbKeybase{
+		db:            db,
+		offlineSigner: TerminalSigner{},
+	}
+	for _, opt := range opts {
+		opt(&kb)
 	}
+	return kb
 }
 
 // CreateMnemonic generates a new key and persists it to storage, encrypted
@@ -69,64 +73,64 @@ func New(db dbm.DB) Keybase {
 // It returns an error if it fails to
 // generate a key for the given algo type, or if another key is
 // already stored under the same name.
-func (kb dbKeybase) CreateMnemonic(name string, language Language, passwd string, algo SigningAlgo) (info Info, mnemonic string, err error) {
-	if language != English {
-		return nil, "", ErrUnsupportedLanguage
-	}
-	if algo != Secp256k1 {
-		err = ErrUnsupportedSigningAlgo
-		return
-	}
-
+//
+// bip39Passphrase is the optional BIP-39 passphrase (the "25th word"): it
+// is distinct from passwd, which only encrypts the key at rest, and a
+// different bip39Passphrase derives an entirely different, hidden wallet
+// from the same mnemonic. Callers that don't need this can pass "".
+func (kb dbKeybase) CreateMnemonic(name string, language Language, passwd string, algo SigningAlgo, bip39Passphrase string) (info Info, mnemonic string, err error) {
 	// default number of words (24):
-	mnemonicS, err := bip39.NewMnemonic(bip39.FreshKey)
+	mnemonicS, err := bip39.NewMnemonic(bip39.FreshKey, language)
 	if err != nil {
 		return
 	}
 	mnemonic = strings.Join(mnemonicS, " ")
-	seed := bip39.MnemonicToSeed(mnemonic)
-	info, err = kb.persistDerivedKey(seed, passwd, name, hd.FullFundraiserPath)
+	seed, err := bip39.MnemonicToSeedWithErrCheckingAndPassphrase(mnemonic, bip39Passphrase)
+	if err != nil {
+		return
+	}
+	info, err = kb.persistDerivedKey(seed, passwd, name, hd.FullFundraiserPath, algo)
 	return
 }
 
 // TEMPORARY METHOD UNTIL WE FIGURE OUT USER FACING HD DERIVATION API
-func (kb dbKeybase) CreateKey(name, mnemonic, passwd string) (info Info, err error) {
+func (kb dbKeybase) CreateKey(name, mnemonic, bip39Passphrase, passwd string) (info Info, err error) {
 	words := strings.Split(mnemonic, " ")
 	if len(words) != 12 && len(words) != 24 {
 		err = fmt.Errorf("recovering only works with 12 word (fundraiser) or 24 word mnemonics, got: %v words", len(words))
 		return
 	}
-	seed, err := bip39.MnemonicToSeedWithErrChecking(mnemonic)
+	seed, err := bip39.MnemonicToSeedWithErrCheckingAndPassphrase(mnemonic, bip39Passphrase)
 	if err != nil {
 		return
 	}
-	info, err = kb.persistDerivedKey(seed, passwd, name, hd.FullFundraiserPath)
+	info, err = kb.persistDerivedKey(seed, passwd, name, hd.FullFundraiserPath, Secp256k1)
 	return
 }
 
 // CreateFundraiserKey converts a mnemonic to a private key and persists it,
 // encrypted with the given password.
 // TODO(ismail)
-func (kb dbKeybase) CreateFundraiserKey(name, mnemonic, passwd string) (info Info, err error) {
+func (kb dbKeybase) CreateFundraiserKey(name, mnemonic, bip39Passphrase, passwd string) (info Info, err error) {
 	words := strings.Split(mnemonic, " ")
 	if len(words) != 12 {
 		err = fmt.Errorf("recovering only works with 12 word (fundraiser), got: %v words", len(words))
 		return
 	}
-	seed, err := bip39.MnemonicToSeedWithErrChecking(mnemonic)
+	seed, err := bip39.MnemonicToSeedWithErrCheckingAndPassphrase(mnemonic, bip39Passphrase)
 	if err != nil {
 		return
 	}
-	info, err = kb.persistDerivedKey(seed, passwd, name, hd.FullFundraiserPath)
+	info, err = kb.persistDerivedKey(seed, passwd, name, hd.FullFundraiserPath, Secp256k1)
 	return
 }
 
-func (kb dbKeybase) Derive(name, mnemonic, passwd string, params hd.BIP44Params) (info Info, err error) {
-	seed, err := bip39.MnemonicToSeedWithErrChecking(mnemonic)
+func (kb dbKeybase) Derive(name, mnemonic, bip39Passphrase, passwd string, params hd.BIP44Params) (info Info, err error) {
+	seed, err := bip39.MnemonicToSeedWithErrCheckingAndPassphrase(mnemonic, bip39Passphrase)
 	if err != nil {
 		return
 	}
-	info, err = kb.persistDerivedKey(seed, passwd, name, params.String())
+	info, err = kb.persistDerivedKey(seed, passwd, name, params.String(), Secp256k1)
 
 	return
 }
@@ -134,10 +138,14 @@ func (kb dbKeybase) Derive(name, mnemonic, passwd string, params hd.BIP44Params)
 // CreateLedger creates a new locally-stored reference to a Ledger keypair
 // It returns the created key info and an error if the Ledger could not be queried
 func (kb dbKeybase) CreateLedger(name string, path crypto.DerivationPath, algo SigningAlgo) (Info, error) {
-	if algo != Secp256k1 {
-		return nil, ErrUnsupportedSigningAlgo
+	sa, err := getSigningAlgo(algo)
+	if err != nil {
+		return nil, err
+	}
+	if sa.Ledger == nil {
+		return nil, fmt.Errorf("%s has no Ledger support", algo)
 	}
-	priv, err := crypto.NewPrivKeyLedgerSecp256k1(path)
+	priv, err := sa.Ledger(path)
 	if err != nil {
 		return nil, err
 	}
@@ -151,10 +159,12 @@ func (kb dbKeybase) CreateOffline(name string, pub tcrypto.PubKey) (Info, error)
 	return kb.writeOfflineKey(pub, name), nil
 }
 
-func (kb *dbKeybase) persistDerivedKey(seed []byte, passwd, name, fullHdPath string) (info Info, err error) {
-	// create master key and derive first key:
-	masterPriv, ch := hd.ComputeMastersFromSeed(seed)
-	derivedPriv, err := hd.DerivePrivateKeyForPath(masterPriv, ch, fullHdPath)
+func (kb *dbKeybase) persistDerivedKey(seed []byte, passwd, name, fullHdPath string, algo SigningAlgo) (info Info, err error) {
+	sa, err := getSigningAlgo(algo)
+	if err != nil {
+		return
+	}
+	priv, err := sa.Derive(seed, fullHdPath)
 	if err != nil {
 		return
 	}
@@ -162,10 +172,9 @@ func (kb *dbKeybase) persistDerivedKey(seed []byte, passwd, name, fullHdPath str
 	// if we have a password, use it to encrypt the private key and store it
 	// else store the public key only
 	if passwd != "" {
-		info = kb.writeLocalKey(tcrypto.PrivKeySecp256k1(derivedPriv), name, passwd)
+		info = kb.writeLocalKey(priv, name, passwd)
 	} else {
-		pubk := tcrypto.PrivKeySecp256k1(derivedPriv).PubKey()
-		info = kb.writeOfflineKey(pubk, name)
+		info = kb.writeOfflineKey(priv.PubKey(), name)
 	}
 	return
 }
@@ -176,6 +185,12 @@ func (kb dbKeybase) List() ([]Info, error) {
 	iter := kb.db.Iterator(nil, nil)
 	defer iter.Close()
 	for ; iter.Valid(); iter.Next() {
+		// The db also holds keyspaces that aren't Info records, such as
+		// subaccounts.go's "<name>.seedinfo" entries - skip anything that
+		// isn't actually stored under infoKey.
+		if !strings.HasSuffix(string(iter.Key()), infoKeySuffix) {
+			continue
+		}
 		info, err := readInfo(iter.Value())
 		if err != nil {
 			return nil, err
@@ -221,15 +236,10 @@ func (kb dbKeybase) Sign(name, passphrase string, msg []byte) (sig tcrypto.Signa
 		}
 	case offlineInfo:
 		linfo := info.(offlineInfo)
-		fmt.Printf("Bytes to sign:\n%s", msg)
-		buf := bufio.NewReader(os.Stdin)
-		fmt.Printf("\nEnter Amino-encoded signature:\n")
-		// Will block until user inputs the signature
-		signed, err := buf.ReadString('\n')
+		sig, err = kb.offlineSigner.Sign(linfo.GetPubKey(), msg)
 		if err != nil {
 			return nil, nil, err
 		}
-		cdc.MustUnmarshalBinary([]byte(signed), sig)
 		return sig, linfo.GetPubKey(), nil
 	}
 	sig, err = priv.Sign(msg)
@@ -407,6 +417,11 @@ func (kb dbKeybase) writeInfo(info Info, name string) {
 	kb.db.SetSync(infoKey(name), writeInfo(info))
 }
 
+// infoKeySuffix is appended to a name to form its storage key; kept as a
+// named const (rather than left implicit in infoKey) so callers that need to
+// recover a name from a raw db key, such as ExportAll, stay in sync with it.
+const infoKeySuffix = ".info"
+
 func infoKey(name string) []byte {
-	return []byte(fmt.Sprintf("%s.info", name))
+	return []byte(name + infoKeySuffix)
 }
@@ -0,0 +1,131 @@
+package keys
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	tcrypto "github.com/tendermint/tendermint/crypto"
+)
+
+// OfflineSigner produces a signature over msg for the key behind pub,
+// without the Keybase ever holding that key's private material itself. It
+// backs every offlineInfo entry created with CreateOffline.
+type OfflineSigner interface {
+	Sign(pub tcrypto.PubKey, msg []byte) (tcrypto.Signature, error)
+}
+
+// TerminalSigner prompts on the controlling terminal and blocks until the
+// operator pastes back an Amino-encoded signature. It is the default
+// OfflineSigner, and is equivalent to what Sign used to do inline.
+type TerminalSigner struct{}
+
+func (TerminalSigner) Sign(pub tcrypto.PubKey, msg []byte) (tcrypto.Signature, error) {
+	fmt.Printf("Bytes to sign:\n%s\n", msg)
+	fmt.Print("Enter Amino-encoded signature:\n")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	var sig tcrypto.Signature
+	if err := cdc.UnmarshalBinaryBare([]byte(strings.TrimSpace(line)), &sig); err != nil {
+		return nil, errors.Wrap(err, "could not parse signature")
+	}
+	return sig, nil
+}
+
+// FileDropSigner hands a signing request to an operator on another, more
+// trusted machine by writing it to Dir and polling for the matching
+// response file, instead of requiring an interactive terminal session.
+type FileDropSigner struct {
+	Dir     string
+	Poll    time.Duration
+	Timeout time.Duration
+}
+
+func (s FileDropSigner) Sign(pub tcrypto.PubKey, msg []byte) (tcrypto.Signature, error) {
+	id := fmt.Sprintf("%X", pub.Address())
+	reqPath := filepath.Join(s.Dir, id+".req")
+	respPath := filepath.Join(s.Dir, id+".sig")
+
+	if err := ioutil.WriteFile(reqPath, msg, 0644); err != nil {
+		return nil, errors.Wrap(err, "writing signing request")
+	}
+
+	poll := s.Poll
+	if poll == 0 {
+		poll = time.Second
+	}
+	deadline := time.Now().Add(s.Timeout)
+	for time.Now().Before(deadline) {
+		bz, err := ioutil.ReadFile(respPath)
+		if err == nil {
+			os.Remove(respPath)
+			var sig tcrypto.Signature
+			if err := cdc.UnmarshalBinaryBare(bz, &sig); err != nil {
+				return nil, errors.Wrap(err, "could not parse signature")
+			}
+			return sig, nil
+		}
+		time.Sleep(poll)
+	}
+	return nil, fmt.Errorf("timed out after %s waiting for a signature in %s", s.Timeout, s.Dir)
+}
+
+// RPCSigner calls out to an HTTP signing service, e.g. an HSM gateway, for
+// every signature: it POSTs a plain JSON object {pub_key, msg} to Endpoint
+// and reads back {signature, error}. It does not use the JSON-RPC 2.0
+// envelope (no jsonrpc, method or id fields).
+type RPCSigner struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+type rpcSignRequest struct {
+	PubKey []byte `json:"pub_key"`
+	Msg    []byte `json:"msg"`
+}
+
+type rpcSignResponse struct {
+	Signature []byte `json:"signature"`
+	Error     string `json:"error,omitempty"`
+}
+
+func (s RPCSigner) Sign(pub tcrypto.PubKey, msg []byte) (tcrypto.Signature, error) {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	body, err := json.Marshal(rpcSignRequest{PubKey: pub.Bytes(), Msg: msg})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Post(s.Endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "calling offline signer")
+	}
+	defer resp.Body.Close()
+
+	var rpcResp rpcSignResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return nil, errors.Wrap(err, "decoding offline signer response")
+	}
+	if rpcResp.Error != "" {
+		return nil, errors.New(rpcResp.Error)
+	}
+
+	var sig tcrypto.Signature
+	if err := cdc.UnmarshalBinaryBare(rpcResp.Signature, &sig); err != nil {
+		return nil, errors.Wrap(err, "could not parse signature")
+	}
+	return sig, nil
+}
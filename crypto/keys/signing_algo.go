@@ -0,0 +1,80 @@
+package keys
+
+import (
+	tcrypto "github.com/tendermint/tendermint/crypto"
+
+	"github.com/cosmos/cosmos-sdk/crypto"
+)
+
+// SigningAlgo names a signing scheme registered with RegisterSigningAlgo.
+type SigningAlgo string
+
+const (
+	// Secp256k1 is the standard Cosmos SDK signing algorithm.
+	Secp256k1 SigningAlgo = "secp256k1"
+	// Ed25519 is commonly used by validators and other tooling that
+	// expects an Ed25519 keypair rather than secp256k1.
+	Ed25519 SigningAlgo = "ed25519"
+	// Secp256k1Eth derives an Ethereum-style address (Keccak256 of the
+	// uncompressed public key) from a secp256k1 keypair, for forks such as
+	// Ethermint that need EVM-compatible accounts.
+	Secp256k1Eth SigningAlgo = "secp256k1-eth"
+)
+
+// deriveFn derives a private key of its algorithm from a BIP-39 seed and an
+// HD path.
+type deriveFn func(seed []byte, hdPath string) (tcrypto.PrivKey, error)
+
+// ledgerFn constructs a reference to a Ledger-resident key of its
+// algorithm. It is nil for algorithms with no Ledger support.
+type ledgerFn func(path crypto.DerivationPath) (tcrypto.PrivKey, error)
+
+// registerFn registers algo's concrete PrivKey/PubKey types on this
+// package's cdc, so Info and key bundle encodings can carry them. It is
+// nil for algorithms whose types are already registered elsewhere - the
+// Secp256k1 and Ed25519 built-ins below ride on tcrypto's own types, which
+// wire.RegisterCrypto registers independently of this package, while
+// Secp256k1Eth registers its own types via registerSecp256k1EthAmino.
+type registerFn func()
+
+// signingAlgo bundles everything CreateMnemonic, CreateLedger and
+// persistDerivedKey need from an algorithm, so none of them have to switch
+// on SigningAlgo directly. There is no separate address-derivation field:
+// Derive already returns the algorithm's own tcrypto.PrivKey, and that
+// type's PubKey().Address() is what derives the address, so it is already
+// per-entry through Derive rather than needing its own hook here.
+type signingAlgo struct {
+	Derive   deriveFn
+	Ledger   ledgerFn
+	Register registerFn
+}
+
+var signingAlgos = map[SigningAlgo]signingAlgo{}
+
+// RegisterSigningAlgo makes algo available to the Keybase via derive (and,
+// for algorithms a Ledger device can hold, ledger - pass nil otherwise).
+// register, if non-nil, is invoked immediately to put algo's concrete
+// PrivKey/PubKey types onto this package's cdc - pass nil if they're
+// already registered some other way (e.g. via wire.RegisterCrypto).
+// Forks that need a signing scheme beyond the built-ins below can call this
+// from their own init() to extend the Keybase without forking it.
+func RegisterSigningAlgo(algo SigningAlgo, derive deriveFn, ledger ledgerFn, register registerFn) {
+	signingAlgos[algo] = signingAlgo{Derive: derive, Ledger: ledger, Register: register}
+	if register != nil {
+		register()
+	}
+}
+
+func getSigningAlgo(algo SigningAlgo) (signingAlgo, error) {
+	sa, ok := signingAlgos[algo]
+	if !ok {
+		return signingAlgo{}, ErrUnsupportedSigningAlgo
+	}
+	return sa, nil
+}
+
+func init() {
+	RegisterSigningAlgo(Secp256k1, secp256k1Derive, secp256k1Ledger, nil)
+	RegisterSigningAlgo(Ed25519, ed25519Derive, nil, nil)
+	RegisterSigningAlgo(Secp256k1Eth, secp256k1EthDerive, nil, registerSecp256k1EthAmino)
+}
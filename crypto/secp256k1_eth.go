@@ -0,0 +1,80 @@
+package crypto
+
+import (
+	secp256k1 "github.com/btcsuite/btcd/btcec"
+	tcrypto "github.com/tendermint/tendermint/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+// PrivKeySecp256k1Eth is a secp256k1 private key whose public key derives
+// an Ethereum-style address (Keccak256 of the uncompressed public key, low
+// 20 bytes) instead of the SDK's usual ripemd160(sha256(...)) address. Used
+// by EVM-compatible forks such as Ethermint.
+type PrivKeySecp256k1Eth [32]byte
+
+func (privKey PrivKeySecp256k1Eth) Bytes() []byte {
+	return cdc.MustMarshalBinaryBare(privKey)
+}
+
+func (privKey PrivKeySecp256k1Eth) Sign(msg []byte) (tcrypto.Signature, error) {
+	priv, _ := secp256k1.PrivKeyFromBytes(secp256k1.S256(), privKey[:])
+	sig, err := priv.Sign(tcrypto.Sha256(msg))
+	if err != nil {
+		return nil, err
+	}
+	return tcrypto.SignatureSecp256k1(sig.Serialize()), nil
+}
+
+func (privKey PrivKeySecp256k1Eth) PubKey() tcrypto.PubKey {
+	_, pub := secp256k1.PrivKeyFromBytes(secp256k1.S256(), privKey[:])
+	var pubSecp PubKeySecp256k1Eth
+	copy(pubSecp[:], pub.SerializeUncompressed())
+	return pubSecp
+}
+
+func (privKey PrivKeySecp256k1Eth) Equals(other tcrypto.PrivKey) bool {
+	otherSecp, ok := other.(PrivKeySecp256k1Eth)
+	return ok && privKey == otherSecp
+}
+
+// PubKeySecp256k1Eth holds an uncompressed secp256k1 public key (65 bytes,
+// 0x04 prefix) so Address() can run Keccak256 over the same bytes an EVM
+// would.
+type PubKeySecp256k1Eth [65]byte
+
+func (pubKey PubKeySecp256k1Eth) Address() tcrypto.Address {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(pubKey[1:])
+	sum := hash.Sum(nil)
+	return tcrypto.Address(sum[12:])
+}
+
+func (pubKey PubKeySecp256k1Eth) Bytes() []byte {
+	return cdc.MustMarshalBinaryBare(pubKey)
+}
+
+func (pubKey PubKeySecp256k1Eth) VerifyBytes(msg []byte, sig tcrypto.Signature) bool {
+	pub, err := secp256k1.ParsePubKey(pubKey[:], secp256k1.S256())
+	if err != nil {
+		return false
+	}
+	sigSecp, ok := sig.(tcrypto.SignatureSecp256k1)
+	if !ok {
+		return false
+	}
+	parsed, err := secp256k1.ParseDERSignature(sigSecp, secp256k1.S256())
+	if err != nil {
+		return false
+	}
+	return parsed.Verify(tcrypto.Sha256(msg), pub)
+}
+
+func (pubKey PubKeySecp256k1Eth) Equals(other tcrypto.PubKey) bool {
+	otherSecp, ok := other.(PubKeySecp256k1Eth)
+	return ok && pubKey == otherSecp
+}
+
+func init() {
+	cdc.RegisterConcrete(PrivKeySecp256k1Eth{}, "cosmos-sdk/PrivKeySecp256k1Eth", nil)
+	cdc.RegisterConcrete(PubKeySecp256k1Eth{}, "cosmos-sdk/PubKeySecp256k1Eth", nil)
+}